@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Provider 描述一个 OpenAI 兼容的后端：地址、鉴权、额外请求头/超时等。
+// 多个模型可以共享同一个 provider（例如同一个网关下的不同 model_id）。
+type Provider struct {
+	BaseURL        string                 `yaml:"base_url"`
+	APIKey         string                 `yaml:"api_key"`
+	Headers        map[string]string      `yaml:"headers,omitempty"`
+	ProxyURL       string                 `yaml:"proxy_url,omitempty"`
+	RequestTimeout int                    `yaml:"request_timeout,omitempty"`
+	ExtraBody      map[string]interface{} `yaml:"extra_body,omitempty"`
+}
+
+// ModelEntry 是用户侧看到的一个模型：显示名 + 它路由到的 provider/model_id。
+type ModelEntry struct {
+	Name         string                 `yaml:"name"`
+	Provider     string                 `yaml:"provider"`
+	ModelID      string                 `yaml:"model_id"`
+	SystemPrompt string                 `yaml:"system_prompt,omitempty"`
+	Params       map[string]interface{} `yaml:"params,omitempty"`
+}
+
+// yamlConfigFile 是 ~/.config/ai-cli/config.yaml 的顶层结构。
+type yamlConfigFile struct {
+	Providers       map[string]Provider `yaml:"providers"`
+	Models          []ModelEntry        `yaml:"models"`
+	DefaultModel    string              `yaml:"default_model,omitempty"`
+	SystemPrompt    string              `yaml:"system_prompt,omitempty"`
+	TranscribeModel string              `yaml:"transcribe_model,omitempty"`
+	TTSModel        string              `yaml:"tts_model,omitempty"`
+	TTSVoice        string              `yaml:"tts_voice,omitempty"`
+	Galleries       []string            `yaml:"galleries,omitempty"`
+}
+
+// defaultProviderName 是由旧版 INI 配置（单一 base_url/api_key）合成出的
+// provider 名称，兼容没有 providers/models 字段的配置文件。
+const defaultProviderName = "default"
+
+// yamlConfigPath 返回新版多 provider 配置文件的路径：~/.config/ai-cli/config.yaml。
+func yamlConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "ai-cli", "config.yaml"), nil
+}
+
+// loadYAMLConfig 读取并解析 YAML 格式的多 provider 配置，填充 cfg。
+func loadYAMLConfig(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f yamlConfigFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if len(f.Models) == 0 {
+		return fmt.Errorf("%s defines no models", path)
+	}
+
+	cfg.ConfigFormat = "yaml"
+	cfg.Providers = f.Providers
+	cfg.Models = f.Models
+	cfg.DefaultModel = f.DefaultModel
+	if cfg.DefaultModel == "" {
+		cfg.DefaultModel = f.Models[0].Name
+	}
+	cfg.SystemPrompt = f.SystemPrompt
+	if cfg.SystemPrompt == "" {
+		cfg.SystemPrompt = detectSystemPrompt()
+	}
+	cfg.TranscribeModel = f.TranscribeModel
+	cfg.TTSModel = f.TTSModel
+	cfg.TTSVoice = f.TTSVoice
+	cfg.Galleries = f.Galleries
+	if cfg.SessionTokenBudget <= 0 {
+		cfg.SessionTokenBudget = defaultSessionTokenBudget
+	}
+	if cfg.ToolOutputLimit <= 0 {
+		cfg.ToolOutputLimit = defaultToolOutputLimit
+	}
+	if cfg.ToolExecTimeout <= 0 {
+		cfg.ToolExecTimeout = defaultToolExecTimeout
+	}
+	return nil
+}
+
+// synthesizeProvidersFromLegacy 把旧版单一 base_url/api_key 配置包装成一个
+// 名为 "default" 的 provider，并把 models 列表转换成指向它的 ModelEntry，
+// 这样 resolveModel 等下游逻辑不需要关心配置来自哪种格式。
+func synthesizeProvidersFromLegacy(modelNames []string) {
+	cfg.Providers = map[string]Provider{
+		defaultProviderName: {
+			BaseURL:        cfg.BaseURL,
+			APIKey:         cfg.APIKey,
+			ProxyURL:       cfg.ProxyURL,
+			RequestTimeout: cfg.RequestTimeout,
+		},
+	}
+	cfg.Models = make([]ModelEntry, 0, len(modelNames))
+	for _, name := range modelNames {
+		cfg.Models = append(cfg.Models, ModelEntry{
+			Name:         name,
+			Provider:     defaultProviderName,
+			ModelID:      name,
+			SystemPrompt: cfg.SystemPrompt,
+		})
+	}
+}
+
+// resolveModel 根据显示名查找对应的 ModelEntry 及其 Provider。
+func resolveModel(name string) (ModelEntry, Provider, error) {
+	for _, m := range cfg.Models {
+		if m.Name == name {
+			p, ok := cfg.Providers[m.Provider]
+			if !ok {
+				return m, Provider{}, fmt.Errorf("model '%s' references unknown provider '%s'", name, m.Provider)
+			}
+			return m, p, nil
+		}
+	}
+	return ModelEntry{}, Provider{}, fmt.Errorf("model '%s' not found in config", name)
+}
+
+// modelNames 返回所有已配置模型的显示名，保持原有顺序。
+func modelNames() []string {
+	names := make([]string, len(cfg.Models))
+	for i, m := range cfg.Models {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// effectiveSystemPrompt 返回某个模型应使用的 system prompt：优先取模型自己的
+// system_prompt，否则回退到全局默认值（detectSystemPrompt() 或旧配置里的
+// system_prompt）。
+func effectiveSystemPrompt(modelName string) string {
+	if m, _, err := resolveModel(modelName); err == nil && m.SystemPrompt != "" {
+		return m.SystemPrompt
+	}
+	return cfg.SystemPrompt
+}
+
+// saveYAMLConfig 把当前 cfg 写回 YAML 配置文件（providers/models/default_model）。
+func saveYAMLConfig(path string) error {
+	f := yamlConfigFile{
+		Providers:       cfg.Providers,
+		Models:          cfg.Models,
+		DefaultModel:    cfg.DefaultModel,
+		SystemPrompt:    cfg.SystemPrompt,
+		TranscribeModel: cfg.TranscribeModel,
+		TTSModel:        cfg.TTSModel,
+		TTSVoice:        cfg.TTSVoice,
+		Galleries:       cfg.Galleries,
+	}
+	b, err := yaml.Marshal(&f)
+	if err != nil {
+		return fmt.Errorf("error marshalling config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultGalleryIndexURL 是未在配置中显式指定 galleries 时使用的默认索引。
+const defaultGalleryIndexURL = "https://raw.githubusercontent.com/ChambersXDU/ai-cli/main/gallery/index.yaml"
+
+// galleriesCacheDirName 是缓存的索引文件在用户缓存目录下的子目录名。
+const galleriesCacheDirName = "galleries"
+
+// GalleryProviderTemplate 描述安装某个预设时应当如何创建/复用它所路由到的
+// provider。APIKeyEnv 指定从哪个环境变量读取密钥，而不是把密钥直接写进索引。
+type GalleryProviderTemplate struct {
+	Name      string            `yaml:"name"`
+	BaseURL   string            `yaml:"base_url"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	APIKeyEnv string            `yaml:"api_key_env,omitempty"`
+}
+
+// GalleryEntry 是画廊索引中的一个模型预设。
+type GalleryEntry struct {
+	Name         string                  `yaml:"name"`
+	Description  string                  `yaml:"description"`
+	Tags         []string                `yaml:"tags,omitempty"`
+	Provider     GalleryProviderTemplate `yaml:"provider"`
+	ModelID      string                  `yaml:"model_id"`
+	SystemPrompt string                  `yaml:"system_prompt,omitempty"`
+	RequiredEnv  []string                `yaml:"required_env,omitempty"`
+}
+
+// GalleryIndex 是一份画廊索引文件（index.yaml）的顶层结构。
+type GalleryIndex struct {
+	Models []GalleryEntry `yaml:"models"`
+}
+
+// galleriesDirPath 返回 ~/.cache/ai-cli/galleries，如不存在则创建。
+func galleriesDirPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user home directory: %w", err)
+	}
+	dir := filepath.Join(home, ".cache", "ai-cli", galleriesCacheDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create galleries cache directory: %w", err)
+	}
+	return dir, nil
+}
+
+// galleryCacheFile 返回某个索引 URL 对应的本地缓存文件路径（按 URL 的 sha1 命名）。
+func galleryCacheFile(indexURL string) (string, error) {
+	dir, err := galleriesDirPath()
+	if err != nil {
+		return "", err
+	}
+	h := sha1.Sum([]byte(indexURL))
+	return filepath.Join(dir, hex.EncodeToString(h[:])+".yaml"), nil
+}
+
+// configuredGalleries 返回配置中要使用的画廊索引 URL 列表，为空时回退到默认值。
+func configuredGalleries() []string {
+	if len(cfg.Galleries) > 0 {
+		return cfg.Galleries
+	}
+	return []string{defaultGalleryIndexURL}
+}
+
+// fetchGalleryIndex 从远端下载一份索引并把结果写入本地缓存。
+func fetchGalleryIndex(indexURL string) (*GalleryIndex, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch gallery index %s: %w", indexURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gallery index %s returned status %d", indexURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var idx GalleryIndex
+	if err := yaml.Unmarshal(body, &idx); err != nil {
+		return nil, fmt.Errorf("could not parse gallery index %s: %w", indexURL, err)
+	}
+
+	if cachePath, err := galleryCacheFile(indexURL); err == nil {
+		_ = os.WriteFile(cachePath, body, 0644)
+	}
+	return &idx, nil
+}
+
+// loadGalleryIndex 优先读取本地缓存，缓存不存在时现拉取一份（并顺便写入缓存）。
+func loadGalleryIndex(indexURL string) (*GalleryIndex, error) {
+	cachePath, err := galleryCacheFile(indexURL)
+	if err == nil {
+		if b, readErr := os.ReadFile(cachePath); readErr == nil {
+			var idx GalleryIndex
+			if yaml.Unmarshal(b, &idx) == nil {
+				return &idx, nil
+			}
+		}
+	}
+	return fetchGalleryIndex(indexURL)
+}
+
+// loadAllGalleryEntries 依次加载所有已配置的画廊索引，跳过取不到的并在 stderr
+// 上报告，返回所有画廊里的全部预设。
+func loadAllGalleryEntries() []GalleryEntry {
+	var entries []GalleryEntry
+	for _, url := range configuredGalleries() {
+		idx, err := loadGalleryIndex(url)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告：无法加载画廊 '%s'：%v\n", url, err)
+			continue
+		}
+		entries = append(entries, idx.Models...)
+	}
+	return entries
+}
+
+// findGalleryEntry 按名称在所有已配置画廊中查找一个预设。
+func findGalleryEntry(name string) (*GalleryEntry, error) {
+	for _, e := range loadAllGalleryEntries() {
+		if e.Name == name {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("gallery 中未找到预设 '%s'", name)
+}
+
+// galleryCmd 是 list/show/install/update 四个子命令的父命令。
+var galleryCmd = &cobra.Command{
+	Use:   "gallery",
+	Short: "浏览并安装远程模型画廊中的预设",
+	Long:  "从配置中 galleries 列出的索引（默认使用仓库自带的 index.yaml）拉取可用的模型预设，并可以一键把预设合并进本地配置。",
+}
+
+var galleryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有画廊中的模型预设",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		tag, _ := cmd.Flags().GetString("tag")
+
+		entries := loadAllGalleryEntries()
+		if tag != "" {
+			var filtered []GalleryEntry
+			for _, e := range entries {
+				for _, t := range e.Tags {
+					if t == tag {
+						filtered = append(filtered, e)
+						break
+					}
+				}
+			}
+			entries = filtered
+		}
+		if len(entries) == 0 {
+			fmt.Println("没有找到匹配的预设。")
+			return
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		for _, e := range entries {
+			fmt.Printf("%s\t%s\t[%s]\n", e.Name, e.Description, strings.Join(e.Tags, ", "))
+		}
+	},
+}
+
+var galleryShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "显示某个预设的详细信息",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		entry, err := findGalleryEntry(args[0])
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("名称: %s\n", entry.Name)
+		fmt.Printf("描述: %s\n", entry.Description)
+		fmt.Printf("标签: %s\n", strings.Join(entry.Tags, ", "))
+		fmt.Printf("Provider: %s (%s)\n", entry.Provider.Name, entry.Provider.BaseURL)
+		fmt.Printf("Model ID: %s\n", entry.ModelID)
+		if entry.SystemPrompt != "" {
+			fmt.Printf("推荐 System Prompt: %s\n", entry.SystemPrompt)
+		}
+		if len(entry.RequiredEnv) > 0 {
+			fmt.Printf("需要的环境变量: %s\n", strings.Join(entry.RequiredEnv, ", "))
+		}
+	},
+}
+
+var galleryInstallCmd = &cobra.Command{
+	Use:   "install <name>",
+	Short: "把一个预设安装（合并）进本地配置",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		entry, err := findGalleryEntry(args[0])
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := installGalleryEntry(entry); err != nil {
+			log.Fatalf("Error installing preset: %v", err)
+		}
+	},
+}
+
+var galleryUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "刷新本地缓存的画廊索引",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		for _, url := range configuredGalleries() {
+			if _, err := fetchGalleryIndex(url); err != nil {
+				fmt.Fprintf(os.Stderr, "警告：刷新画廊 '%s' 失败：%v\n", url, err)
+				continue
+			}
+			fmt.Printf("已刷新画廊索引 '%s'\n", url)
+		}
+	},
+}
+
+// installGalleryEntry 把一个画廊预设合并进当前配置：缺失的 provider 会被创建
+// （需要的环境变量会在缺失时交互式提示用户输入并 os.Setenv），随后把预设追加
+// 到 models，并询问是否将其设为默认模型。
+func installGalleryEntry(entry *GalleryEntry) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	for _, envName := range entry.RequiredEnv {
+		if os.Getenv(envName) != "" {
+			continue
+		}
+		fmt.Printf("预设 '%s' 需要环境变量 %s，请输入其值: ", entry.Name, envName)
+		value, _ := reader.ReadString('\n')
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("缺少必需的环境变量 %s，安装已取消", envName)
+		}
+		os.Setenv(envName, value)
+	}
+
+	for _, m := range cfg.Models {
+		if m.Name == entry.Name {
+			return fmt.Errorf("模型 '%s' 已存在于配置中", entry.Name)
+		}
+	}
+
+	providerName := entry.Provider.Name
+	if providerName == "" {
+		providerName = entry.Name
+	}
+	if cfg.Providers == nil {
+		cfg.Providers = map[string]Provider{}
+	}
+	if _, ok := cfg.Providers[providerName]; !ok {
+		apiKey := ""
+		if entry.Provider.APIKeyEnv != "" {
+			apiKey = os.Getenv(entry.Provider.APIKeyEnv)
+		}
+		cfg.Providers[providerName] = Provider{
+			BaseURL: entry.Provider.BaseURL,
+			APIKey:  apiKey,
+			Headers: entry.Provider.Headers,
+		}
+	}
+
+	cfg.Models = append(cfg.Models, ModelEntry{
+		Name:         entry.Name,
+		Provider:     providerName,
+		ModelID:      entry.ModelID,
+		SystemPrompt: entry.SystemPrompt,
+	})
+
+	if cfg.ConfigFormat != "yaml" {
+		fmt.Fprintln(os.Stderr, "警告：当前使用旧版 INI 配置，只有模型名会被持久化；要保留完整的 provider 路由信息，请迁移到 ~/.config/ai-cli/config.yaml。")
+	}
+
+	fmt.Printf("是否将 '%s' 设为默认模型？[y/N]: ", entry.Name)
+	answer, _ := reader.ReadString('\n')
+	if strings.EqualFold(strings.TrimSpace(answer), "y") {
+		cfg.DefaultModel = entry.Name
+	}
+
+	if err := saveConfig(); err != nil {
+		return err
+	}
+	fmt.Printf("预设 '%s' 已安装。\n", entry.Name)
+	return nil
+}
+
+func init() {
+	galleryListCmd.Flags().String("tag", "", "Only list presets with this tag")
+	galleryCmd.AddCommand(galleryListCmd, galleryShowCmd, galleryInstallCmd, galleryUpdateCmd)
+	rootCmd.AddCommand(galleryCmd)
+}
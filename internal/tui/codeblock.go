@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+// copyLastCodeBlock 在历史的助手消息中从后往前查找最后一个 ``` 代码块并写入系统剪贴板，
+// 返回一条适合展示在状态栏的结果说明。
+func copyLastCodeBlock(history []ChatMessage) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "assistant" {
+			continue
+		}
+		if block, ok := lastCodeBlock(history[i].Content); ok {
+			if err := clipboard.WriteAll(block); err != nil {
+				return fmt.Sprintf("复制失败: %v", err)
+			}
+			return "已复制最后一个代码块到剪贴板"
+		}
+	}
+	return "未找到代码块"
+}
+
+// lastCodeBlock 提取一段文本中最后一个被 ``` 包裹的代码块内容（去掉围栏和语言标签）。
+func lastCodeBlock(content string) (string, bool) {
+	parts := strings.Split(content, "```")
+	if len(parts) < 3 {
+		return "", false
+	}
+	// parts 形如 [前文, 代码1, 中间文, 代码2, ...]；取最后一个奇数下标段。
+	for i := len(parts) - 1; i >= 0; i-- {
+		if i%2 == 1 {
+			block := parts[i]
+			if nl := strings.Index(block, "\n"); nl != -1 {
+				firstLine := strings.TrimSpace(block[:nl])
+				if firstLine != "" && !strings.Contains(firstLine, " ") {
+					block = block[nl+1:] // 去掉语言标签行
+				}
+			}
+			return strings.TrimRight(block, "\n"), true
+		}
+	}
+	return "", false
+}
+
+// saveTranscript 把对话历史以 Markdown 格式写入磁盘，返回状态栏提示文本。
+func saveTranscript(path string, history []ChatMessage) string {
+	if path == "" {
+		path = fmt.Sprintf("ai-cli-transcript-%s.md", time.Now().Format("20060102-150405"))
+	}
+	var b strings.Builder
+	for _, msg := range history {
+		switch msg.Role {
+		case "user":
+			b.WriteString("### 你\n\n")
+		case "assistant":
+			b.WriteString("### 助手\n\n")
+		default:
+			continue
+		}
+		b.WriteString(msg.Content + "\n\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Sprintf("保存失败: %v", err)
+	}
+	return "已保存到 " + path
+}
@@ -0,0 +1,313 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	userStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	assistantStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	statusStyle    = lipgloss.NewStyle().Faint(true)
+	helpStyle      = lipgloss.NewStyle().Faint(true)
+)
+
+// model 是 REPL 的 Bubble Tea 状态机。
+type model struct {
+	opts     Options
+	program  *tea.Program
+	viewport viewport.Model
+	textarea textarea.Model
+	spinner  spinner.Model
+	renderer *glamour.TermRenderer
+
+	history      []ChatMessage
+	currentModel string
+
+	waiting       bool
+	waitingSince  time.Time
+	streaming     strings.Builder
+	pendingDeltas chan string
+
+	pickerOpen bool
+	pickerIdx  int
+
+	width, height int
+	statusMsg     string
+}
+
+// deltaMsg 携带一个流式增量片段。
+type deltaMsg string
+
+// doneMsg 在一次请求结束（成功或失败）时发出。
+type doneMsg struct {
+	full string
+	err  error
+}
+
+// tickMsg 不会被发送，Update 也不处理它；等待回复时的计时显示随
+// spinner.TickMsg 触发的重绘刷新。
+type tickMsg time.Time
+
+func newModel(opts Options) *model {
+	ta := textarea.New()
+	ta.Placeholder = "输入消息，Enter 发送，Alt+Enter 换行..."
+	ta.Focus()
+	ta.ShowLineNumbers = false
+	ta.SetHeight(3)
+
+	vp := viewport.New(80, 20)
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+
+	renderer, _ := glamour.NewTermRenderer(glamour.WithAutoStyle(), glamour.WithWordWrap(80))
+
+	history := append([]ChatMessage{}, opts.History...)
+	if len(history) == 0 && opts.SystemPrompt != "" {
+		history = append(history, ChatMessage{Role: "system", Content: opts.SystemPrompt})
+	}
+
+	return &model{
+		opts:         opts,
+		viewport:     vp,
+		textarea:     ta,
+		spinner:      sp,
+		renderer:     renderer,
+		history:      history,
+		currentModel: opts.InitialModel,
+	}
+}
+
+func (m *model) Init() tea.Cmd {
+	return tea.Batch(textarea.Blink, m.spinner.Tick)
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.viewport.Width = msg.Width
+		m.viewport.Height = msg.Height - 6
+		m.textarea.SetWidth(msg.Width)
+		m.refreshTranscript()
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case deltaMsg:
+		m.streaming.WriteString(string(msg))
+		m.refreshTranscript()
+		return m, m.awaitNext()
+
+	case doneMsg:
+		m.waiting = false
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("错误: %v", msg.err)
+		} else if msg.full != "" {
+			m.history = append(m.history, ChatMessage{Role: "assistant", Content: msg.full})
+		}
+		m.streaming.Reset()
+		m.refreshTranscript()
+		return m, nil
+	}
+
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	cmds = append(cmds, cmd)
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+func (m *model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pickerOpen {
+		return m.handlePickerKey(msg)
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "esc":
+		return m, tea.Quit
+	case "ctrl+m":
+		m.pickerOpen = true
+		m.pickerIdx = 0
+		return m, nil
+	case "ctrl+y":
+		m.statusMsg = copyLastCodeBlock(m.history)
+		return m, nil
+	case "ctrl+r":
+		return m, m.regenerate()
+	case "ctrl+s":
+		m.statusMsg = saveTranscript(m.opts.TranscriptPath, m.history)
+		return m, nil
+	case "alt+enter":
+		m.textarea.InsertString("\n")
+		return m, nil
+	case "enter":
+		if m.waiting {
+			return m, nil
+		}
+		text := strings.TrimSpace(m.textarea.Value())
+		if text == "" {
+			return m, nil
+		}
+		m.textarea.Reset()
+		m.history = append(m.history, ChatMessage{Role: "user", Content: text})
+		m.refreshTranscript()
+		return m, m.send()
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+func (m *model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.pickerOpen = false
+		return m, nil
+	case "up", "k":
+		if m.pickerIdx > 0 {
+			m.pickerIdx--
+		}
+		return m, nil
+	case "down", "j":
+		if m.pickerIdx < len(m.opts.Models)-1 {
+			m.pickerIdx++
+		}
+		return m, nil
+	case "enter":
+		if m.pickerIdx < len(m.opts.Models) {
+			m.currentModel = m.opts.Models[m.pickerIdx]
+			m.statusMsg = "已切换到模型 " + m.currentModel
+		}
+		m.pickerOpen = false
+		return m, nil
+	}
+	return m, nil
+}
+
+// send 以当前历史发起一次请求；SSE 增量写入一个 channel，由 awaitNext 逐个
+// 转换为 deltaMsg，请求结束时的 doneMsg 经 program.Send 异步送回。
+func (m *model) send() tea.Cmd {
+	m.waiting = true
+	m.waitingSince = time.Now()
+	history := append([]ChatMessage{}, m.history...)
+	model := m.currentModel
+	deltas := make(chan string, 64)
+
+	go func() {
+		full, err := m.opts.Send(history, model, func(chunk string) {
+			deltas <- chunk
+		})
+		close(deltas)
+		m.program.Send(doneMsg{full: full, err: err})
+	}()
+
+	m.pendingDeltas = deltas
+	return m.awaitNext()
+}
+
+// regenerate 重新发送最后一轮用户消息（丢弃上一条助手回复）。
+func (m *model) regenerate() tea.Cmd {
+	for i := len(m.history) - 1; i >= 0; i-- {
+		if m.history[i].Role == "assistant" {
+			m.history = append(m.history[:i], m.history[i+1:]...)
+			break
+		}
+	}
+	return m.send()
+}
+
+// awaitNext 从当前请求的增量 channel 中取出下一个 chunk，转换为 Bubble Tea 消息。
+func (m *model) awaitNext() tea.Cmd {
+	ch := m.pendingDeltas
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		chunk, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return deltaMsg(chunk)
+	}
+}
+
+func (m *model) refreshTranscript() {
+	var b strings.Builder
+	for _, msg := range m.history {
+		switch msg.Role {
+		case "user":
+			b.WriteString(userStyle.Render("你") + "\n")
+		case "assistant":
+			b.WriteString(assistantStyle.Render("助手") + "\n")
+		default:
+			continue
+		}
+		if m.renderer != nil {
+			if out, err := m.renderer.Render(msg.Content); err == nil {
+				b.WriteString(out)
+				continue
+			}
+		}
+		b.WriteString(msg.Content + "\n\n")
+	}
+	if m.streaming.Len() > 0 {
+		b.WriteString(assistantStyle.Render("助手") + "\n" + m.streaming.String() + "\n")
+	}
+	m.viewport.SetContent(b.String())
+	m.viewport.GotoBottom()
+}
+
+func (m *model) View() string {
+	if m.pickerOpen {
+		return m.pickerView()
+	}
+
+	var status string
+	if m.waiting {
+		elapsed := time.Since(m.waitingSince).Round(time.Second)
+		status = fmt.Sprintf("%s 等待回复... %s", m.spinner.View(), elapsed)
+	} else if m.statusMsg != "" {
+		status = m.statusMsg
+	}
+
+	help := helpStyle.Render("Ctrl-M 切换模型 · Ctrl-Y 复制代码块 · Ctrl-R 重新生成 · Ctrl-S 保存 · Esc 退出")
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s",
+		m.viewport.View(),
+		statusStyle.Render(fmt.Sprintf("模型: %s  %s", m.currentModel, status)),
+		m.textarea.View(),
+		help,
+	)
+}
+
+func (m *model) pickerView() string {
+	var b strings.Builder
+	b.WriteString("选择模型 (↑/↓ 选择, Enter 确认, Esc 取消)\n\n")
+	for i, name := range m.opts.Models {
+		cursor := "  "
+		if i == m.pickerIdx {
+			cursor = "> "
+		}
+		b.WriteString(cursor + name + "\n")
+	}
+	return b.String()
+}
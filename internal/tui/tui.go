@@ -0,0 +1,48 @@
+// Package tui 实现 ai-cli 的全屏交互界面（基于 Bubble Tea / Lipgloss / Glamour）。
+//
+// main 包在非 TTY 场景下仍然走原有的逐行流式输出路径（见 main.go 中的
+// callAPI / streamChatCompletion），不依赖本包；只有在需要全屏 REPL 时才会
+// 调用 tui.Run。两条路径通过 ChatMessage / SendFunc 解耦，互不感知彼此的实现。
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ChatMessage 是一条对话消息，字段与 main 包中的 Message 保持一致，
+// 避免 internal/tui 反向依赖 main 包。
+type ChatMessage struct {
+	Role    string
+	Content string
+}
+
+// SendFunc 向后端发起一次 chat completion 请求。onDelta 会在每个 SSE chunk
+// 到达时被调用一次，用于把增量内容追加到当前的助手气泡中；返回值是拼接后的
+// 完整回复文本。
+type SendFunc func(history []ChatMessage, model string, onDelta func(string)) (string, error)
+
+// Options 配置一次 REPL 会话。
+type Options struct {
+	// Models 是 Ctrl-M 模型切换器可选的模型列表（通常来自 cfg.Models）。
+	Models []string
+	// InitialModel 是启动时使用的模型（通常来自 cfg.DefaultModel）。
+	InitialModel string
+	// SystemPrompt 在 History 为空时作为第一条 system 消息注入历史。
+	SystemPrompt string
+	// History 允许以已有的对话历史启动界面；非空时不再注入 SystemPrompt。
+	History []ChatMessage
+	// Send 执行实际的网络请求。
+	Send SendFunc
+	// TranscriptPath 是 Ctrl-S 保存会话记录时写入的文件路径；为空时使用
+	// 当前目录下的 ai-cli-transcript-<timestamp>.md。
+	TranscriptPath string
+}
+
+// Run 启动全屏 Bubble Tea 程序并阻塞直到用户退出。
+func Run(opts Options) error {
+	m := newModel(opts)
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	m.program = p
+	_, err := p.Run()
+	return err
+}
@@ -19,34 +19,115 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// Config 结构体用于存储配置
+// Config 结构体用于存储配置。
+//
+// APIKey/BaseURL/RequestTimeout/SystemPrompt/ProxyURL 是从旧版 INI 风格配置
+// 文件（~/.ai_cli_config）读取出的“单一后端”设置；使用旧版配置时，它们会被
+// 合成为一个名为 "default" 的 Provider。使用新版 YAML 配置
+// （~/.config/ai-cli/config.yaml，见 config.go）时不会读取这些字段。
+// 两种情况下真正生效的路由信息都在 Providers/Models 里。
 type Config struct {
-	APIKey         string
-	BaseURL        string
-	DefaultModel   string
-	Models         []string
-	RequestTimeout int
-	SystemPrompt   string
-	ProxyURL       string
+	APIKey             string
+	BaseURL            string
+	DefaultModel       string
+	Models             []ModelEntry
+	Providers          map[string]Provider
+	RequestTimeout     int
+	SystemPrompt       string
+	ProxyURL           string
+	SessionTokenBudget int
+	ToolsAllowlist     []string
+	ToolOutputLimit    int
+	ToolExecTimeout    int
+	TranscribeModel    string
+	TTSModel           string
+	TTSVoice           string
+	Galleries          []string
+	// ConfigFormat 记录配置来自 "yaml" 还是 "ini"，决定 saveConfig 写回哪种格式。
+	ConfigFormat string
 }
 
 // API 请求和响应的结构体
 type APIRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream"`
+	Model      string    `json:"model"`
+	Messages   []Message `json:"messages"`
+	Stream     bool      `json:"stream"`
+	Tools      []Tool    `json:"tools,omitempty"`
+	ToolChoice string    `json:"tool_choice,omitempty"`
 }
 
+// Message 是一条对话消息。Content 用 json.RawMessage 承载，因为 OpenAI 兼容
+// API 既接受普通字符串 content（文本对话），也接受 {type, text/image_url}
+// 数组（多模态输入，见 multimodal.go）。用 textMessage/textContent 构造纯文本
+// 消息，用 visionMessage 构造带图片的消息，不要直接给 Content 赋字面量。
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []ToolCall      `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	Name       string          `json:"name,omitempty"`
+}
+
+// textContent 把纯文本包装成 Message.Content 期望的 JSON 形式（一个 JSON 字符串）。
+func textContent(text string) json.RawMessage {
+	b, _ := json.Marshal(text)
+	return json.RawMessage(b)
+}
+
+// textMessage 构造一条纯文本消息。
+func textMessage(role, text string) Message {
+	return Message{Role: role, Content: textContent(text)}
+}
+
+// messageText 尝试把 Content 当作普通字符串读出；如果它是多模态数组，
+// 返回 ok=false。
+func messageText(m Message) (string, bool) {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// Tool 描述一个可供模型调用的函数，遵循 OpenAI 的 tools 字段格式。
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToolCall 是模型请求执行的一次函数调用。
+type ToolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 type StreamResponse struct {
 	Choices []struct {
 		Delta struct {
-			Content string `json:"content"`
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
 		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 }
 
@@ -90,7 +171,7 @@ var rootCmd = &cobra.Command{
 	Short: "A command-line AI assistant",
 	Long: `A fast and simple command-line AI assistant that connects to OpenAI-compatible APIs.
 It reads your prompt from the command line arguments or from standard input (stdin).`,
-	Args:  cobra.ArbitraryArgs,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 1. 加载配置
 		if err := loadConfig(); err != nil {
@@ -107,7 +188,13 @@ It reads your prompt from the command line arguments or from standard input (std
 		if len(args) > 0 {
 			prompt = strings.Join(args, " ")
 		} else {
-			// 如果没有命令行参数，则从 stdin 读取
+			// 如果没有命令行参数，且标准输入/输出都连接到真实终端，
+			// 则默认打开全屏 REPL（见 repl.go），而不是等待一次性 prompt。
+			if isInteractiveTerminal() {
+				runREPL()
+				return
+			}
+			// 否则从 stdin 读取一次性 prompt（管道/重定向场景）
 			stat, _ := os.Stdin.Stat()
 			if (stat.Mode() & os.ModeCharDevice) == 0 {
 				stdinBytes, err := io.ReadAll(os.Stdin)
@@ -123,7 +210,23 @@ It reads your prompt from the command line arguments or from standard input (std
 			return
 		}
 
-		// 4. 执行 API 调用
+		// 4. --agent 开启带工具调用的 actor 模式（见 tools.go），--no-tools 强制禁用
+		agentFlag, _ := cmd.Flags().GetBool("agent")
+		noToolsFlag, _ := cmd.Flags().GetBool("no-tools")
+		if agentFlag && !noToolsFlag {
+			runAgent(prompt)
+			return
+		}
+
+		// 5. 如指定了 --session/--continue，则走带历史记录的会话分支
+		sessionFlag, _ := cmd.Flags().GetString("session")
+		continueFlag, _ := cmd.Flags().GetBool("continue")
+		if sessionFlag != "" || continueFlag {
+			runSessionTurn(sessionFlag, continueFlag, prompt)
+			return
+		}
+
+		// 6. 执行一次性（无记忆）API 调用
 		callAPI(prompt)
 	},
 }
@@ -131,9 +234,13 @@ It reads your prompt from the command line arguments or from standard input (std
 func init() {
 	// 定义命令行标志
 	rootCmd.PersistentFlags().StringP("model", "m", "", "Specify the model to use (overrides config default)")
+	rootCmd.Flags().String("session", "", "Attach to (or create) a named chat session, persisting history")
+	rootCmd.Flags().Bool("continue", false, "Continue the last used chat session")
+	rootCmd.Flags().Bool("agent", false, "Allow the model to propose shell commands to run (with confirmation)")
+	rootCmd.Flags().Bool("no-tools", false, "Force-disable tool calling even if --agent is set")
 
-	// 添加 model、add、remove 子命令
-	rootCmd.AddCommand(modelCmd, addCmd, removeCmd)
+	// 添加 model、add、remove、chat、sessions 子命令
+	rootCmd.AddCommand(modelCmd, addCmd, removeCmd, chatCmd, sessionsCmd)
 }
 
 // modelCmd: 交互式选择或通过参数设置默认模型
@@ -157,13 +264,13 @@ var modelCmd = &cobra.Command{
 			name := args[0]
 			found := false
 			for _, m := range cfg.Models {
-				if m == name {
+				if m.Name == name {
 					found = true
 					break
 				}
 			}
 			if !found {
-				fmt.Fprintf(os.Stderr, "模型 '%s' 未在配置中找到。可用模型：%s\n", name, strings.Join(cfg.Models, ", "))
+				fmt.Fprintf(os.Stderr, "模型 '%s' 未在配置中找到。可用模型：%s\n", name, strings.Join(modelNames(), ", "))
 				return
 			}
 
@@ -179,10 +286,10 @@ var modelCmd = &cobra.Command{
 		fmt.Println("可用模型：")
 		for i, m := range cfg.Models {
 			mark := " "
-			if m == cfg.DefaultModel {
+			if m.Name == cfg.DefaultModel {
 				mark = "*"
 			}
-			fmt.Printf("[%d] %s %s\n", i+1, m, mark)
+			fmt.Printf("[%d] %s %s\n", i+1, m.Name, mark)
 		}
 
 		reader := bufio.NewReader(os.Stdin)
@@ -200,7 +307,7 @@ var modelCmd = &cobra.Command{
 			return
 		}
 
-		cfg.DefaultModel = cfg.Models[idx-1]
+		cfg.DefaultModel = cfg.Models[idx-1].Name
 		if err := saveConfig(); err != nil {
 			log.Fatalf("Failed to save config: %v", err)
 		}
@@ -209,6 +316,9 @@ var modelCmd = &cobra.Command{
 }
 
 // 新增模型命令: ai-cli add <model>
+// 新增的模型会路由到 "default" provider（由旧版 base_url/api_key/proxy_url
+// 合成，或在 YAML 配置中已存在），model_id 与显示名相同。需要路由到其它
+// provider 的场景请直接编辑 ~/.config/ai-cli/config.yaml（见 config.go）。
 var addCmd = &cobra.Command{
 	Use:   "add [model]",
 	Short: "添加一个模型到配置列表",
@@ -223,12 +333,23 @@ var addCmd = &cobra.Command{
 			return
 		}
 		for _, m := range cfg.Models {
-			if m == name {
+			if m.Name == name {
 				fmt.Fprintf(os.Stderr, "模型 '%s' 已存在。\n", name)
 				return
 			}
 		}
-		cfg.Models = append(cfg.Models, name)
+		if _, ok := cfg.Providers[defaultProviderName]; !ok {
+			if cfg.Providers == nil {
+				cfg.Providers = map[string]Provider{}
+			}
+			cfg.Providers[defaultProviderName] = Provider{
+				BaseURL:        cfg.BaseURL,
+				APIKey:         cfg.APIKey,
+				ProxyURL:       cfg.ProxyURL,
+				RequestTimeout: cfg.RequestTimeout,
+			}
+		}
+		cfg.Models = append(cfg.Models, ModelEntry{Name: name, Provider: defaultProviderName, ModelID: name})
 		if cfg.DefaultModel == "" {
 			cfg.DefaultModel = name
 		}
@@ -254,10 +375,10 @@ var removeCmd = &cobra.Command{
 		fmt.Println("可用模型：")
 		for i, m := range cfg.Models {
 			mark := " "
-			if m == cfg.DefaultModel {
+			if m.Name == cfg.DefaultModel {
 				mark = "*"
 			}
-			fmt.Printf("[%d] %s %s\n", i+1, m, mark)
+			fmt.Printf("[%d] %s %s\n", i+1, m.Name, mark)
 		}
 
 		reader := bufio.NewReader(os.Stdin)
@@ -273,13 +394,13 @@ var removeCmd = &cobra.Command{
 			fmt.Fprintln(os.Stderr, "无效的选择。")
 			return
 		}
-		removed := cfg.Models[idx-1]
+		removed := cfg.Models[idx-1].Name
 		// 从切片中移除
 		cfg.Models = append(cfg.Models[:idx-1], cfg.Models[idx:]...)
 		// 如果删除的是默认模型，重置为第一个或清空
 		if cfg.DefaultModel == removed {
 			if len(cfg.Models) > 0 {
-				cfg.DefaultModel = cfg.Models[0]
+				cfg.DefaultModel = cfg.Models[0].Name
 			} else {
 				cfg.DefaultModel = ""
 			}
@@ -299,8 +420,19 @@ func configFilePath() (string, error) {
 	}
 	return filepath.Join(home, configName), nil
 }
-// saveConfig 将当前 cfg 中的 default_model 和 models 写回配置文件（保持其它行不变）
+
+// saveConfig 把当前 cfg 写回磁盘。如果配置来自新版 YAML 文件，则整份重新
+// 序列化；如果来自旧版 INI 文件，则只原地替换 default_model/models 两行，
+// 其它内容保持不变（兼容用户手写的注释等）。
 func saveConfig() error {
+	if cfg.ConfigFormat == "yaml" {
+		path, err := yamlConfigPath()
+		if err != nil {
+			return err
+		}
+		return saveYAMLConfig(path)
+	}
+
 	path, err := configFilePath()
 	if err != nil {
 		return err
@@ -316,9 +448,9 @@ func saveConfig() error {
 		s += "\n" + fmt.Sprintf("default_model = %s", cfg.DefaultModel)
 	}
 	if strings.Contains(s, "models") {
-		s = replaceLine(s, "models", fmt.Sprintf("models = %s", strings.Join(cfg.Models, ", ")))
+		s = replaceLine(s, "models", fmt.Sprintf("models = %s", strings.Join(modelNames(), ", ")))
 	} else {
-		s += "\n" + fmt.Sprintf("models = %s", strings.Join(cfg.Models, ", "))
+		s += "\n" + fmt.Sprintf("models = %s", strings.Join(modelNames(), ", "))
 	}
 	return os.WriteFile(path, []byte(s), 0644)
 }
@@ -336,7 +468,16 @@ func replaceLine(s, key, newLine string) string {
 	return strings.Join(out, "\n")
 }
 
+// loadConfig 加载配置，优先使用新版多 provider 的 YAML 配置
+// （~/.config/ai-cli/config.yaml），不存在时回退到旧版单一后端的 INI 风格
+// 配置文件（~/.ai_cli_config），两者都不存在则创建后者的默认模板。
 func loadConfig() error {
+	if yamlPath, err := yamlConfigPath(); err == nil {
+		if _, statErr := os.Stat(yamlPath); statErr == nil {
+			return loadYAMLConfig(yamlPath)
+		}
+	}
+
 	path, err := configFilePath()
 	if err != nil {
 		return err
@@ -347,7 +488,17 @@ func loadConfig() error {
 	}
 
 	// 默认值
-	cfg = Config{BaseURL: "https://api.openai.com/v1", DefaultModel: "gpt-4o-mini", Models: []string{"gpt-4o-mini"}, RequestTimeout: 30, SystemPrompt: detectSystemPrompt()}
+	cfg = Config{
+		BaseURL:            "https://api.openai.com/v1",
+		DefaultModel:       "gpt-4o-mini",
+		RequestTimeout:     30,
+		SystemPrompt:       detectSystemPrompt(),
+		SessionTokenBudget: defaultSessionTokenBudget,
+		ToolOutputLimit:    defaultToolOutputLimit,
+		ToolExecTimeout:    defaultToolExecTimeout,
+		ConfigFormat:       "ini",
+	}
+	modelList := []string{"gpt-4o-mini"}
 
 	f, err := os.Open(path)
 	if err != nil {
@@ -385,7 +536,7 @@ func loadConfig() error {
 					}
 				}
 				if len(ms) > 0 {
-					cfg.Models = ms
+					modelList = ms
 				}
 			}
 		case "request_timeout":
@@ -396,6 +547,44 @@ func loadConfig() error {
 			cfg.SystemPrompt = v
 		case "proxy_url":
 			cfg.ProxyURL = v
+		case "session_token_budget":
+			if t, e := strconv.Atoi(v); e == nil {
+				cfg.SessionTokenBudget = t
+			}
+		case "tools_allowlist":
+			if v != "" {
+				var allow []string
+				for _, t := range strings.Split(v, ",") {
+					if t := strings.TrimSpace(t); t != "" {
+						allow = append(allow, t)
+					}
+				}
+				cfg.ToolsAllowlist = allow
+			}
+		case "tool_output_limit":
+			if t, e := strconv.Atoi(v); e == nil {
+				cfg.ToolOutputLimit = t
+			}
+		case "tool_exec_timeout":
+			if t, e := strconv.Atoi(v); e == nil {
+				cfg.ToolExecTimeout = t
+			}
+		case "transcribe_model":
+			cfg.TranscribeModel = v
+		case "tts_model":
+			cfg.TTSModel = v
+		case "tts_voice":
+			cfg.TTSVoice = v
+		case "galleries":
+			if v != "" {
+				var galleries []string
+				for _, g := range strings.Split(v, ",") {
+					if t := strings.TrimSpace(g); t != "" {
+						galleries = append(galleries, t)
+					}
+				}
+				cfg.Galleries = galleries
+			}
 		}
 	}
 
@@ -403,8 +592,18 @@ func loadConfig() error {
 		return fmt.Errorf("API key is missing or not set in %s. Please edit the file and set your API key", path)
 	}
 	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
-	if len(cfg.Models) == 0 {
-		cfg.Models = []string{cfg.DefaultModel}
+	if len(modelList) == 0 {
+		modelList = []string{cfg.DefaultModel}
+	}
+	synthesizeProvidersFromLegacy(modelList)
+	if cfg.SessionTokenBudget <= 0 {
+		cfg.SessionTokenBudget = defaultSessionTokenBudget
+	}
+	if cfg.ToolOutputLimit <= 0 {
+		cfg.ToolOutputLimit = defaultToolOutputLimit
+	}
+	if cfg.ToolExecTimeout <= 0 {
+		cfg.ToolExecTimeout = defaultToolExecTimeout
 	}
 	return nil
 }
@@ -433,8 +632,34 @@ system_prompt = %s
 
 # Optional: Specify a proxy URL if needed (e.g., http://127.0.0.1:7890)
 # Leave blank if you don't need a proxy
-proxy_url = 
-`, detectSystemPrompt())
+proxy_url =
+
+# Maximum number of (approximate) tokens to keep in a chat session's history
+# before the oldest turns are trimmed. See 'ai-cli chat' / 'ai-cli sessions'.
+session_token_budget = %d
+
+# Comma-separated list of shell commands (by executable name) that --agent
+# is allowed to run without an interactive [y/n/a] confirmation prompt.
+tools_allowlist =
+
+# Maximum number of bytes of a tool's stdout+stderr kept before it is
+# truncated when fed back to the model.
+tool_output_limit = %d
+
+# Timeout in seconds for a single --agent tool execution.
+tool_exec_timeout = %d
+
+# Model used by 'ai-cli transcribe' (OpenAI-compatible /audio/transcriptions)
+transcribe_model = %s
+
+# Model and voice used by 'ai-cli speak' (OpenAI-compatible /audio/speech)
+tts_model = %s
+tts_voice = %s
+
+# Comma-separated list of gallery index URLs for 'ai-cli gallery'.
+# Leave blank to use the built-in default index.
+galleries =
+`, detectSystemPrompt(), defaultSessionTokenBudget, defaultToolOutputLimit, defaultToolExecTimeout, defaultTranscribeModel, defaultTTSModel, defaultTTSVoice)
 	if err := os.WriteFile(path, []byte(cfg), 0644); err != nil {
 		return err
 	}
@@ -446,58 +671,120 @@ proxy_url =
 func callAPI(prompt string) {
 	// 构建消息
 	messages := []Message{}
-	if cfg.SystemPrompt != "" {
-		messages = append(messages, Message{Role: "system", Content: cfg.SystemPrompt})
+	if sp := effectiveSystemPrompt(cfg.DefaultModel); sp != "" {
+		messages = append(messages, textMessage("system", sp))
+	}
+	messages = append(messages, textMessage("user", prompt))
+
+	if _, err := streamChatCompletion(messages, cfg.DefaultModel, os.Stdout); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// marshalRequestWithExtras 把基础请求体序列化为 JSON，并依次合并 provider 的
+// extra_body 与模型自己的 params（后者优先级更高），让 ~/.config/ai-cli/config.yaml
+// 里声明的 extra_body/params 真正进入请求，而不是被悄悄丢弃。
+func marshalRequestWithExtras(reqBody APIRequest, extraBody, params map[string]interface{}) ([]byte, error) {
+	base, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(extraBody) == 0 && len(params) == 0 {
+		return base, nil
+	}
+
+	var merged map[string]interface{}
+	if err := json.Unmarshal(base, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range extraBody {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+// postChatCompletion 构建并发送一次 /chat/completions 请求（总是 stream=true），
+// 供 streamChatCompletion 与 streamChatCompletionWithTools（tools.go）共用。
+// 调用方负责关闭返回的响应体。
+func postChatCompletion(messages []Message, model string, tools []Tool) (*http.Response, error) {
+	entry, provider, err := resolveModel(model)
+	if err != nil {
+		return nil, err
 	}
-	messages = append(messages, Message{Role: "user", Content: prompt})
 
-	// 构建请求体
 	reqBody := APIRequest{
-		Model:    cfg.DefaultModel,
+		Model:    entry.ModelID,
 		Messages: messages,
 		Stream:   true,
 	}
+	if len(tools) > 0 {
+		reqBody.Tools = tools
+		reqBody.ToolChoice = "auto"
+	}
 
-	jsonData, err := json.Marshal(reqBody)
+	jsonData, err := marshalRequestWithExtras(reqBody, provider.ExtraBody, entry.Params)
 	if err != nil {
-		log.Fatalf("Error marshalling request: %v", err)
+		return nil, fmt.Errorf("error marshalling request: %w", err)
+	}
+
+	timeout := provider.RequestTimeout
+	if timeout <= 0 {
+		timeout = cfg.RequestTimeout
 	}
 
 	// 创建 HTTP 客户端，并配置代理（如果需要）
 	httpClient := &http.Client{
-		Timeout: time.Duration(cfg.RequestTimeout) * time.Second,
+		Timeout: time.Duration(timeout) * time.Second,
 	}
-	if cfg.ProxyURL != "" {
-		proxyURL, err := url.Parse(cfg.ProxyURL)
+	if provider.ProxyURL != "" {
+		proxyURL, err := url.Parse(provider.ProxyURL)
 		if err != nil {
-			log.Fatalf("Invalid proxy_url: %v", err)
+			return nil, fmt.Errorf("invalid proxy_url for provider '%s': %w", entry.Provider, err)
 		}
 		httpClient.Transport = &http.Transport{
 			Proxy: http.ProxyURL(proxyURL),
 		}
 	}
 
-	// 创建请求
-	req, err := http.NewRequest("POST", cfg.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", strings.TrimRight(provider.BaseURL, "/")+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Fatalf("Error creating request: %v", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
+	for k, v := range provider.Headers {
+		req.Header.Set(k, v)
+	}
 
-	// 发送请求
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.Fatalf("Error making request: %v", err)
+		return nil, fmt.Errorf("error making request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Fatalf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		resp.Body.Close()
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return resp, nil
+}
+
+// streamChatCompletion 向配置的 API 发起一次流式 chat completion 请求，
+// 将增量内容原样写入 w，并返回拼接后的完整回复文本。
+// 这是 callAPI 与会话子系统（session.go）共用的核心请求逻辑。
+func streamChatCompletion(messages []Message, model string, w io.Writer) (string, error) {
+	resp, err := postChatCompletion(messages, model, nil)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
 	// 处理流式响应
+	var full strings.Builder
 	scanner := bufio.NewScanner(resp.Body)
 	// increase buffer to support long SSE lines (tokens) from some providers
 	buf := make([]byte, 0, 64*1024)
@@ -517,15 +804,19 @@ func callAPI(prompt string) {
 			}
 
 			if len(streamResp.Choices) > 0 {
-				fmt.Print(streamResp.Choices[0].Delta.Content)
+				delta := streamResp.Choices[0].Delta.Content
+				fmt.Fprint(w, delta)
+				full.WriteString(delta)
 			}
 		}
 	}
-	fmt.Println() // 在结束后打印一个换行符
+	fmt.Fprintln(w) // 在结束后打印一个换行符
 
 	if err := scanner.Err(); err != nil {
-		log.Fatalf("Error reading stream: %v", err)
+		return full.String(), fmt.Errorf("error reading stream: %w", err)
 	}
+
+	return full.String(), nil
 }
 
 func main() {
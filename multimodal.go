@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// 多模态子命令使用的默认模型/声音，可通过配置文件覆盖（见 loadConfig）。
+const (
+	defaultTranscribeModel = "whisper-1"
+	defaultTTSModel        = "tts-1"
+	defaultTTSVoice        = "alloy"
+)
+
+// ContentPart 是 OpenAI 多模态 content 数组里的一个元素：一段文本或一张图片。
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// visionMessage 构造一条携带图片的 user 消息：text 部分 + image_url 部分。
+func visionMessage(text, imageURL string) Message {
+	parts := []ContentPart{
+		{Type: "text", Text: text},
+		{Type: "image_url", ImageURL: &ImageURL{URL: imageURL}},
+	}
+	b, _ := json.Marshal(parts)
+	return Message{Role: "user", Content: json.RawMessage(b)}
+}
+
+// visionCmd: ai-cli vision <image-path-or-url> [prompt]
+var visionCmd = &cobra.Command{
+	Use:   "vision <image-path-or-url> [prompt]",
+	Short: "就一张图片向模型提问（多模态）",
+	Long:  "向支持视觉输入的模型发送一张图片（本地文件会被 base64 编码为 data: URL，http(s) 链接原样传递）及可选的文字提示，流式输出回复。",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		if modelFlag, _ := cmd.Flags().GetString("model"); modelFlag != "" {
+			cfg.DefaultModel = modelFlag
+		}
+
+		imageArg := args[0]
+		prompt := strings.TrimSpace(strings.Join(args[1:], " "))
+		if prompt == "" {
+			prompt = "Describe this image."
+		}
+
+		imageURL, err := resolveImageURL(imageArg)
+		if err != nil {
+			log.Fatalf("Error reading image: %v", err)
+		}
+
+		messages := []Message{}
+		if sp := effectiveSystemPrompt(cfg.DefaultModel); sp != "" {
+			messages = append(messages, textMessage("system", sp))
+		}
+		messages = append(messages, visionMessage(prompt, imageURL))
+
+		if _, err := streamChatCompletion(messages, cfg.DefaultModel, os.Stdout); err != nil {
+			log.Fatalf("%v", err)
+		}
+	},
+}
+
+// resolveImageURL 把一个本地路径或 http(s) URL 规整成可以直接放进 image_url
+// 字段的字符串：远程链接原样返回，本地文件读取后按扩展名猜测 MIME 类型并
+// 编码成 base64 data: URL。
+func resolveImageURL(pathOrURL string) (string, error) {
+	if strings.HasPrefix(pathOrURL, "http://") || strings.HasPrefix(pathOrURL, "https://") {
+		return pathOrURL, nil
+	}
+
+	data, err := os.ReadFile(pathOrURL)
+	if err != nil {
+		return "", err
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(pathOrURL))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, encoded), nil
+}
+
+// transcribeCmd: ai-cli transcribe <audio>
+var transcribeCmd = &cobra.Command{
+	Use:   "transcribe <audio>",
+	Short: "把一段音频转写为文字",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		text, err := transcribeAudio(args[0], transcribeModel())
+		if err != nil {
+			log.Fatalf("Error transcribing audio: %v", err)
+		}
+		fmt.Println(text)
+	},
+}
+
+// transcribeAudio 以 multipart/form-data 的形式把音频文件 POST 到
+// /audio/transcriptions，返回模型转写出的文字。
+func transcribeAudio(audioPath, model string) (string, error) {
+	_, provider, err := resolveModel(cfg.DefaultModel)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(audioPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", err
+	}
+	if err := writer.WriteField("model", model); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", providerURL(provider, "/audio/transcriptions"), &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
+
+	client := providerHTTPClient(provider)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcription request failed with status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("could not parse transcription response: %w", err)
+	}
+	return result.Text, nil
+}
+
+// speakCmd: ai-cli speak <text> [--voice alloy] [--out out.mp3]
+var speakCmd = &cobra.Command{
+	Use:   "speak <text>",
+	Short: "把文字合成为语音",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		voice, _ := cmd.Flags().GetString("voice")
+		out, _ := cmd.Flags().GetString("out")
+		if voice == "" {
+			voice = ttsVoice()
+		}
+
+		audio, err := synthesizeSpeech(args[0], ttsModel(), voice)
+		if err != nil {
+			log.Fatalf("Error synthesizing speech: %v", err)
+		}
+
+		if out == "" {
+			if _, err := os.Stdout.Write(audio); err != nil {
+				log.Fatalf("Error writing audio to stdout: %v", err)
+			}
+			return
+		}
+		if err := os.WriteFile(out, audio, 0644); err != nil {
+			log.Fatalf("Error writing audio to %s: %v", out, err)
+		}
+	},
+}
+
+// synthesizeSpeech POST 到 /audio/speech，返回原始音频字节。
+func synthesizeSpeech(text, model, voice string) ([]byte, error) {
+	_, provider, err := resolveModel(cfg.DefaultModel)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(map[string]string{
+		"model": model,
+		"input": text,
+		"voice": voice,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", providerURL(provider, "/audio/speech"), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+provider.APIKey)
+
+	client := providerHTTPClient(provider)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("speech request failed with status %d: %s", resp.StatusCode, audio)
+	}
+	return audio, nil
+}
+
+// providerURL 拼接某个 provider 的 base_url 与一个 API 路径。
+func providerURL(provider Provider, path string) string {
+	return strings.TrimRight(provider.BaseURL, "/") + path
+}
+
+// providerHTTPClient 按 provider 的超时/代理设置构建一个 http.Client。
+func providerHTTPClient(provider Provider) *http.Client {
+	timeout := provider.RequestTimeout
+	if timeout <= 0 {
+		timeout = cfg.RequestTimeout
+	}
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	if provider.ProxyURL != "" {
+		if proxyURL, err := url.Parse(provider.ProxyURL); err == nil {
+			client.Transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+		}
+	}
+	return client
+}
+
+func transcribeModel() string {
+	if cfg.TranscribeModel != "" {
+		return cfg.TranscribeModel
+	}
+	return defaultTranscribeModel
+}
+
+func ttsModel() string {
+	if cfg.TTSModel != "" {
+		return cfg.TTSModel
+	}
+	return defaultTTSModel
+}
+
+func ttsVoice() string {
+	if cfg.TTSVoice != "" {
+		return cfg.TTSVoice
+	}
+	return defaultTTSVoice
+}
+
+func init() {
+	speakCmd.Flags().String("voice", "", fmt.Sprintf("Voice to use for speech synthesis (default %q)", defaultTTSVoice))
+	speakCmd.Flags().String("out", "", "Write audio to this file instead of stdout")
+	rootCmd.AddCommand(visionCmd, transcribeCmd, speakCmd)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ChambersXDU/ai-cli/internal/tui"
+)
+
+// replCmd 打开全屏的 Bubble Tea 交互界面。非 TTY 场景（管道/重定向）下应使用
+// 默认的单次调用或 'ai-cli chat'，REPL 依赖真实终端渲染 Markdown 与输入框。
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "打开全屏交互式对话界面",
+	Long:  "打开一个基于 Bubble Tea 的全屏界面：可滚动的 Markdown 对话记录、多行输入框，以及模型切换、复制代码块、重新生成等快捷键。",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		if modelFlag, _ := cmd.Flags().GetString("model"); modelFlag != "" {
+			cfg.DefaultModel = modelFlag
+		}
+		runREPL()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+}
+
+// isInteractiveTerminal 判断标准输入/输出是否都连接到真实终端，用于决定是否
+// 默认进入 REPL（而不是读取 stdin 作为一次性 prompt）。
+func isInteractiveTerminal() bool {
+	inStat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	outStat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return (inStat.Mode()&os.ModeCharDevice) != 0 && (outStat.Mode()&os.ModeCharDevice) != 0
+}
+
+// runREPL 把 main 包的配置与流式请求逻辑适配成 internal/tui 所需的接口后启动界面。
+func runREPL() {
+	opts := tui.Options{
+		Models:       modelNames(),
+		InitialModel: cfg.DefaultModel,
+		SystemPrompt: effectiveSystemPrompt(cfg.DefaultModel),
+		Send:         sendForTUI,
+	}
+	if err := tui.Run(opts); err != nil {
+		log.Fatalf("REPL exited with error: %v", err)
+	}
+}
+
+// sendForTUI 实现 tui.SendFunc：把 tui.ChatMessage 历史转换为 Message，调用
+// streamChatCompletion，并把每个 SSE chunk 转发给 onDelta。
+func sendForTUI(history []tui.ChatMessage, model string, onDelta func(string)) (string, error) {
+	messages := make([]Message, len(history))
+	for i, h := range history {
+		messages[i] = textMessage(h.Role, h.Content)
+	}
+	return streamChatCompletion(messages, model, deltaWriter{onDelta})
+}
+
+// deltaWriter 适配 io.Writer，把每次 Write 的字节转发给 onDelta 回调，
+// 用于把 streamChatCompletion 的输出接入 TUI 的流式气泡而非 stdout。
+type deltaWriter struct {
+	onDelta func(string)
+}
+
+func (d deltaWriter) Write(p []byte) (int, error) {
+	d.onDelta(string(p))
+	return len(p), nil
+}
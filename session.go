@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionsDirName 是会话历史在用户主目录下的存放目录名。
+const sessionsDirName = ".ai_cli_sessions"
+
+// defaultSessionTokenBudget 是会话历史的默认 token 预算（近似计算），
+// 超出后会从最旧的非 system 消息开始裁剪。
+const defaultSessionTokenBudget = 8000
+
+// lastSessionFile 记录最近一次使用的会话 ID，供 --continue 使用。
+const lastSessionFile = ".last"
+
+// Session 是持久化到磁盘的一次对话，包含完整历史与少量元数据。
+type Session struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Messages  []Message `json:"messages"`
+}
+
+// sessionsDirPath 返回 ~/.ai_cli_sessions，如不存在则创建。
+func sessionsDirPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user home directory: %w", err)
+	}
+	dir := filepath.Join(home, sessionsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create sessions directory: %w", err)
+	}
+	return dir, nil
+}
+
+func sessionFilePath(id string) (string, error) {
+	dir, err := sessionsDirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+// newSessionID 生成一个按时间排序友好的会话 ID，例如 20260727-153012。
+func newSessionID() string {
+	return time.Now().Format("20060102-150405")
+}
+
+// titleFromPrompt 从用户的第一轮输入中截取一个简短标题。
+func titleFromPrompt(prompt string) string {
+	t := strings.TrimSpace(strings.SplitN(prompt, "\n", 2)[0])
+	const maxLen = 40
+	r := []rune(t)
+	if len(r) > maxLen {
+		t = string(r[:maxLen]) + "..."
+	}
+	if t == "" {
+		t = "(untitled)"
+	}
+	return t
+}
+
+// loadSession 从磁盘读取一个已存在的会话。
+func loadSession(id string) (*Session, error) {
+	path, err := sessionFilePath(id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("session '%s' not found: %w", id, err)
+	}
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("session '%s' is corrupt: %w", id, err)
+	}
+	return &s, nil
+}
+
+// save 将会话写回磁盘，并把它记录为最近使用的会话。
+func (s *Session) save() error {
+	s.UpdatedAt = time.Now()
+	path, err := sessionFilePath(s.ID)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling session: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return err
+	}
+	return setLastSessionID(s.ID)
+}
+
+func setLastSessionID(id string) error {
+	dir, err := sessionsDirPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, lastSessionFile), []byte(id), 0644)
+}
+
+func lastSessionID() (string, error) {
+	dir, err := sessionsDirPath()
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(filepath.Join(dir, lastSessionFile))
+	if err != nil {
+		return "", fmt.Errorf("no session has been used yet")
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// listSessions 返回所有已保存的会话，按最近更新时间倒序排列。
+func listSessions() ([]*Session, error) {
+	dir, err := sessionsDirPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), ".json")
+		s, err := loadSession(id)
+		if err != nil {
+			continue // 跳过损坏的会话文件
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// deleteSession 删除指定会话文件。
+func deleteSession(id string) error {
+	path, err := sessionFilePath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("session '%s' not found: %w", id, err)
+	}
+	return nil
+}
+
+// estimateTokens 粗略估算一组消息占用的 token 数（约 4 字符 = 1 token）。
+func estimateTokens(messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += len(m.Content) / 4
+	}
+	return total
+}
+
+// trimHistory 在历史超出 token 预算时，从最旧的非 system 消息开始丢弃，
+// 直到回到预算以内，或只剩 system 消息加最后一条消息（最后一条总会保留）。
+func trimHistory(messages []Message, budget int) []Message {
+	if budget <= 0 || estimateTokens(messages) <= budget {
+		return messages
+	}
+	start := 0
+	if len(messages) > 0 && messages[0].Role == "system" {
+		start = 1
+	}
+	for estimateTokens(messages) > budget && len(messages) > start+1 {
+		messages = append(messages[:start], messages[start+1:]...)
+	}
+	return messages
+}
+
+// getOrCreateSession 根据 --session/--continue 解析出要使用的会话，
+// 若不存在同名会话则新建一个。
+func getOrCreateSession(sessionFlag string, continueFlag bool) (*Session, error) {
+	id := sessionFlag
+	if id == "" && continueFlag {
+		last, err := lastSessionID()
+		if err != nil {
+			return nil, err
+		}
+		id = last
+	}
+	if id == "" {
+		id = newSessionID()
+	}
+
+	if s, err := loadSession(id); err == nil {
+		return s, nil
+	}
+
+	return &Session{
+		ID:        id,
+		Model:     cfg.DefaultModel,
+		CreatedAt: time.Now(),
+		Messages:  []Message{},
+	}, nil
+}
+
+// runSessionTurn 处理 'ai-cli --session <id> "<prompt>"' / '--continue' 这种
+// 单轮但带历史记录的调用：追加用户发言、流式输出回复、写回会话文件。
+func runSessionTurn(sessionFlag string, continueFlag bool, prompt string) {
+	session, err := getOrCreateSession(sessionFlag, continueFlag)
+	if err != nil {
+		log.Fatalf("Error resolving session: %v", err)
+	}
+	appendTurnAndRespond(session, prompt)
+}
+
+// appendTurnAndRespond 把一轮用户输入加入会话历史、调用 API 流式输出回复，
+// 并把结果（包括裁剪后的历史）落盘。
+func appendTurnAndRespond(session *Session, prompt string) {
+	if len(session.Messages) == 0 {
+		session.Model = cfg.DefaultModel
+		if sp := effectiveSystemPrompt(session.Model); sp != "" {
+			session.Messages = append(session.Messages, textMessage("system", sp))
+		}
+		session.Title = titleFromPrompt(prompt)
+	}
+	session.Messages = append(session.Messages, textMessage("user", prompt))
+
+	reply, err := streamChatCompletion(session.Messages, session.Model, os.Stdout)
+	if reply != "" {
+		session.Messages = append(session.Messages, textMessage("assistant", reply))
+	}
+	session.Messages = trimHistory(session.Messages, cfg.SessionTokenBudget)
+
+	if saveErr := session.save(); saveErr != nil {
+		log.Printf("Warning: failed to save session '%s': %v", session.ID, saveErr)
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// chatCmd 开启一个交互式、带记忆的多轮对话（在终端中持续读取输入行）。
+var chatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "开始一个持久化的多轮对话会话",
+	Long:  "开始一个新的（或通过 --session/--continue 附加到已有的）对话会话，在终端中逐行读取输入，每轮的历史都会写回磁盘。",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		if modelFlag, _ := cmd.Flags().GetString("model"); modelFlag != "" {
+			cfg.DefaultModel = modelFlag
+		}
+		sessionFlag, _ := cmd.Flags().GetString("session")
+		continueFlag, _ := cmd.Flags().GetBool("continue")
+
+		session, err := getOrCreateSession(sessionFlag, continueFlag)
+		if err != nil {
+			log.Fatalf("Error resolving session: %v", err)
+		}
+		fmt.Printf("已附加到会话 '%s'（输入 exit 或 Ctrl-D 结束）\n", session.ID)
+
+		reader := bufio.NewScanner(os.Stdin)
+		for {
+			fmt.Print("> ")
+			if !reader.Scan() {
+				break
+			}
+			line := strings.TrimSpace(reader.Text())
+			if line == "" {
+				continue
+			}
+			if line == "exit" || line == "quit" {
+				break
+			}
+			appendTurnAndRespond(session, line)
+		}
+	},
+}
+
+// sessionsCmd 是 list/resume/rm 三个会话管理子命令的父命令。
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "管理持久化的对话会话",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有已保存的会话",
+	Run: func(cmd *cobra.Command, args []string) {
+		sessions, err := listSessions()
+		if err != nil {
+			log.Fatalf("Error listing sessions: %v", err)
+		}
+		if len(sessions) == 0 {
+			fmt.Println("没有已保存的会话。")
+			return
+		}
+		for _, s := range sessions {
+			fmt.Printf("%s\t%s\t%s\t(%d 条消息)\n", s.ID, s.Model, s.Title, len(s.Messages))
+		}
+	},
+}
+
+var sessionsResumeCmd = &cobra.Command{
+	Use:   "resume <id>",
+	Short: "恢复一个已有会话并继续对话",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfig(); err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		session, err := loadSession(args[0])
+		if err != nil {
+			log.Fatalf("Error loading session: %v", err)
+		}
+		fmt.Printf("已恢复会话 '%s'（%d 条历史消息，输入 exit 或 Ctrl-D 结束）\n", session.ID, len(session.Messages))
+
+		reader := bufio.NewScanner(os.Stdin)
+		for {
+			fmt.Print("> ")
+			if !reader.Scan() {
+				break
+			}
+			line := strings.TrimSpace(reader.Text())
+			if line == "" {
+				continue
+			}
+			if line == "exit" || line == "quit" {
+				break
+			}
+			appendTurnAndRespond(session, line)
+		}
+	},
+}
+
+var sessionsRmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "删除一个会话",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deleteSession(args[0]); err != nil {
+			log.Fatalf("Error deleting session: %v", err)
+		}
+		fmt.Printf("会话 '%s' 已删除。\n", args[0])
+	},
+}
+
+func init() {
+	chatCmd.Flags().String("session", "", "Attach to (or create) a named chat session")
+	chatCmd.Flags().Bool("continue", false, "Continue the last used chat session")
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsResumeCmd, sessionsRmCmd)
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultToolOutputLimit 是截断工具输出时保留的默认字节数。
+const defaultToolOutputLimit = 4000
+
+// defaultToolExecTimeout 是单次工具执行的默认超时时间（秒）。
+const defaultToolExecTimeout = 30
+
+// runShellToolName 是内置 shell 执行工具向模型暴露的函数名。
+const runShellToolName = "run_shell"
+
+// runShellTool 描述内置的本地 shell 执行工具，仅在 --agent 模式下注册。
+func runShellTool() Tool {
+	return Tool{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        runShellToolName,
+			Description: "Execute a shell command on the user's local machine and return its stdout/stderr. Requires interactive user confirmation.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"command": {"type": "string", "description": "The shell command to execute"},
+					"explanation": {"type": "string", "description": "A short human-readable explanation of what the command does"}
+				},
+				"required": ["command", "explanation"]
+			}`),
+		},
+	}
+}
+
+// toolsAlwaysAllow 记录用户是否已经选择了 [a]lways，使本次进程内后续的工具调用
+// 不再逐次确认。
+var toolsAlwaysAllow bool
+
+// runAgent 实现 --agent 模式的对话循环：把 run_shell 工具暴露给模型，
+// 每当模型请求调用工具时征得用户确认、执行并把结果喂回模型，
+// 直到模型返回一条不含工具调用的最终回复。
+func runAgent(prompt string) {
+	messages := []Message{}
+	if sp := effectiveSystemPrompt(cfg.DefaultModel); sp != "" {
+		messages = append(messages, textMessage("system", sp))
+	}
+	messages = append(messages, textMessage("user", prompt))
+
+	tools := []Tool{runShellTool()}
+
+	for {
+		assistantMsg, finishReason, err := streamChatCompletionWithTools(messages, cfg.DefaultModel, tools, os.Stdout)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		messages = append(messages, assistantMsg)
+
+		if finishReason != "tool_calls" || len(assistantMsg.ToolCalls) == 0 {
+			return
+		}
+
+		for _, tc := range assistantMsg.ToolCalls {
+			result := handleToolCall(tc)
+			messages = append(messages, Message{Role: "tool", ToolCallID: tc.ID, Content: textContent(result)})
+		}
+	}
+}
+
+// streamChatCompletionWithTools 与 streamChatCompletion 类似，但同时累积
+// delta.tool_calls 片段（它们按 SSE 事件里的 index 分片到达，参数是逐段拼接
+// 的 JSON 字符串），并在结束后返回组装好的 assistant 消息及 finish_reason。
+func streamChatCompletionWithTools(messages []Message, model string, tools []Tool, w io.Writer) (Message, string, error) {
+	resp, err := postChatCompletion(messages, model, tools)
+	if err != nil {
+		return Message{}, "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	callsByIndex := make(map[int]*ToolCall)
+	var order []int
+	var finishReason string
+
+	scanner := bufio.NewScanner(resp.Body)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			break
+		}
+
+		var sr StreamResponse
+		if err := json.Unmarshal([]byte(data), &sr); err != nil {
+			continue
+		}
+		if len(sr.Choices) == 0 {
+			continue
+		}
+		choice := sr.Choices[0]
+
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+		if choice.Delta.Content != "" {
+			fmt.Fprint(w, choice.Delta.Content)
+			content.WriteString(choice.Delta.Content)
+		}
+		for _, tcd := range choice.Delta.ToolCalls {
+			tc, ok := callsByIndex[tcd.Index]
+			if !ok {
+				tc = &ToolCall{Type: "function"}
+				callsByIndex[tcd.Index] = tc
+				order = append(order, tcd.Index)
+			}
+			if tcd.ID != "" {
+				tc.ID = tcd.ID
+			}
+			if tcd.Type != "" {
+				tc.Type = tcd.Type
+			}
+			tc.Function.Name += tcd.Function.Name
+			tc.Function.Arguments += tcd.Function.Arguments
+		}
+	}
+	fmt.Fprintln(w)
+
+	if err := scanner.Err(); err != nil {
+		return Message{}, finishReason, fmt.Errorf("error reading stream: %w", err)
+	}
+
+	sort.Ints(order)
+	var calls []ToolCall
+	for _, idx := range order {
+		calls = append(calls, *callsByIndex[idx])
+	}
+
+	msg := Message{Role: "assistant", Content: textContent(content.String()), ToolCalls: calls}
+	return msg, finishReason, nil
+}
+
+// shellToolArgs 是 run_shell 工具调用的参数 JSON 结构。
+type shellToolArgs struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation"`
+}
+
+// handleToolCall 解析一次工具调用、征得用户确认、执行命令，并返回要喂回模型的
+// 工具输出（已按 cfg.ToolOutputLimit 截断）。
+func handleToolCall(tc ToolCall) string {
+	if tc.Function.Name != runShellToolName {
+		return fmt.Sprintf("error: unknown tool '%s'", tc.Function.Name)
+	}
+
+	var args shellToolArgs
+	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+		return fmt.Sprintf("error: could not parse tool arguments: %v", err)
+	}
+
+	if !confirmShellCommand(args) {
+		return "error: user declined to run this command"
+	}
+
+	output, err := execShellCommand(args.Command, time.Duration(cfg.ToolExecTimeout)*time.Second)
+	output = truncateToolOutput(output, cfg.ToolOutputLimit)
+	if err != nil {
+		return fmt.Sprintf("exit error: %v\noutput:\n%s", err, output)
+	}
+	return output
+}
+
+// confirmShellCommand 打印命令及其说明，并在 TTY 上以 [y]es/[n]o/[a]lways 的
+// 形式请求用户确认。allowlist 中的命令、以及此前选择过 always 的会话会跳过提示。
+func confirmShellCommand(args shellToolArgs) bool {
+	if toolsAlwaysAllow || isAllowlisted(args.Command, cfg.ToolsAllowlist) {
+		return true
+	}
+
+	fmt.Printf("\n模型想要执行命令:\n  %s\n说明: %s\n", args.Command, args.Explanation)
+	fmt.Print("允许执行吗？[y]es/[n]o/[a]lways: ")
+
+	// 优先从终端读取确认，这样 prompt 经管道传入 stdin 时也能交互；
+	// 打不开 /dev/tty 时回退到 stdin。
+	var in io.Reader = os.Stdin
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		in = tty
+	}
+	var input string
+	fmt.Fscanln(in, &input)
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "y", "yes":
+		return true
+	case "a", "always":
+		toolsAlwaysAllow = true
+		return true
+	default:
+		return false
+	}
+}
+
+// isAllowlisted 检查命令的可执行文件名是否在 tools_allowlist 中。
+func isAllowlisted(command string, allowlist []string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	bin := fields[0]
+	for _, a := range allowlist {
+		if a == bin {
+			return true
+		}
+	}
+	return false
+}
+
+// execShellCommand 在一个可取消的超时内通过 /bin/sh -c 执行命令，
+// 返回合并后的 stdout+stderr。
+func execShellCommand(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// truncateToolOutput 把工具输出截断到 limit 字节，避免把超大输出喂回模型。
+func truncateToolOutput(output string, limit int) string {
+	if limit <= 0 || len(output) <= limit {
+		return output
+	}
+	return output[:limit] + fmt.Sprintf("\n... (truncated, %d bytes total)", len(output))
+}